@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
+)
+
+var legacyRequests = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "sink_legacy_requests_total",
+		Help: "The total requests served by the deprecated /{key} handler",
+	},
+)
+
+// envelope is the response shape for every /api/v1 endpoint except
+// /api/v1/openapi.json, which serves the bare OpenAPI document.
+type envelope struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func writeEnvelope(w http.ResponseWriter, status int, data interface{}, errMsg string) {
+	env := envelope{Data: data, Error: errMsg}
+	if errMsg == "" {
+		env.Status = "success"
+	} else {
+		env.Status = "error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(env)
+}
+
+// registerV1Routes wires up the /api/v1 surface: versioned film CRUD,
+// a health check, and the generated OpenAPI document.
+func registerV1Routes(r *mux.Router) {
+	api := r.PathPrefix("/api/v1").Subrouter()
+
+	api.HandleFunc("/films", v1ListFilms).Methods("GET")
+	api.HandleFunc("/films/{key}", v1GetFilm).Methods("GET")
+	api.HandleFunc("/films/{key}", v1PutFilm).Methods("PUT")
+	api.HandleFunc("/films/{key}", v1PostFilm).Methods("POST")
+	api.HandleFunc("/films/{key}", v1DeleteFilm).Methods("DELETE")
+	api.HandleFunc("/healthz", v1Healthz).Methods("GET")
+	api.HandleFunc("/openapi.json", v1OpenAPI).Methods("GET")
+}
+
+func v1ListFilms(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	films, err := store.List(query.Get("name"), query.Get("year"))
+	if err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	if films == nil {
+		films = []film{}
+	}
+
+	writeEnvelope(w, http.StatusOK, films, "")
+}
+
+func v1GetFilm(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	f, ok, err := store.Get(key)
+	if err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	if !ok {
+		writeEnvelope(w, http.StatusNotFound, nil, "film not found")
+		return
+	}
+
+	writeEnvelope(w, http.StatusOK, f, "")
+}
+
+func v1PutFilm(w http.ResponseWriter, r *http.Request) {
+	v1StoreFilm(w, r, http.StatusOK)
+}
+
+func v1PostFilm(w http.ResponseWriter, r *http.Request) {
+	v1StoreFilm(w, r, http.StatusCreated)
+}
+
+// v1StoreFilm implements both PUT and POST for /api/v1/films/{key};
+// the two only differ in the success status code.
+func v1StoreFilm(w http.ResponseWriter, r *http.Request, successStatus int) {
+	key := mux.Vars(r)["key"]
+
+	body, err := decompressBody(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		writeEnvelope(w, http.StatusUnsupportedMediaType, nil, err.Error())
+		return
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+
+	var f film
+	if err := json.Unmarshal(data, &f); err != nil {
+		writeEnvelope(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	if err := f.validate(); err != nil {
+		writeEnvelope(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+
+	if err := store.Put(key, f); err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+
+	writeEnvelope(w, successStatus, f, "")
+}
+
+func v1DeleteFilm(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if err := store.Delete(key); err != nil {
+		writeEnvelope(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+
+	writeEnvelope(w, http.StatusOK, nil, "")
+}
+
+func v1Healthz(w http.ResponseWriter, r *http.Request) {
+	writeEnvelope(w, http.StatusOK, map[string]string{"status": "ok"}, "")
+}
+
+func v1OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildOpenAPISpec())
+}
+
+// legacyHandler preserves the original, unversioned /{key} behavior
+// while logging and counting so we can see migration progress towards
+// /api/v1/films/{key}.
+func legacyHandler(w http.ResponseWriter, r *http.Request) {
+	log.WithFields(logrus.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	}).Warn("deprecated /{key} endpoint used, prefer /api/v1/films/{key}")
+
+	legacyRequests.Inc()
+
+	mainHandler(w, r)
+}
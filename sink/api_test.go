@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newTestRouter() *mux.Router {
+	r := mux.NewRouter()
+	registerV1Routes(r)
+	return r
+}
+
+func TestV1FilmRoundTrip(t *testing.T) {
+	store = newMemoryStore()
+	r := newTestRouter()
+
+	body := strings.NewReader(`{"title":"Arrival","year":2016}`)
+	req := httptest.NewRequest("PUT", "/api/v1/films/a", body)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d, body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var putEnv envelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &putEnv); err != nil {
+		t.Fatalf("decoding PUT response: %v", err)
+	}
+	if putEnv.Status != "success" {
+		t.Fatalf("PUT envelope status = %q, want %q", putEnv.Status, "success")
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/films/a", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d, body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var getEnv envelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &getEnv); err != nil {
+		t.Fatalf("decoding GET response: %v", err)
+	}
+	data, err := json.Marshal(getEnv.Data)
+	if err != nil {
+		t.Fatalf("re-marshaling envelope data: %v", err)
+	}
+	var got film
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decoding film from envelope data: %v", err)
+	}
+	if got.Title != "Arrival" || got.Year != 2016 {
+		t.Fatalf("GET returned %+v, want Title=Arrival Year=2016", got)
+	}
+
+	req = httptest.NewRequest("DELETE", "/api/v1/films/a", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, want %d, body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/films/a", nil)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("GET after DELETE status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestV1GetFilmNotFound(t *testing.T) {
+	store = newMemoryStore()
+	r := newTestRouter()
+
+	req := httptest.NewRequest("GET", "/api/v1/films/missing", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if env.Status != "error" || env.Error == "" {
+		t.Fatalf("envelope = %+v, want an error status and message", env)
+	}
+}
+
+func TestBuildOpenAPISpecIsValidJSONWithExpectedPaths(t *testing.T) {
+	spec := buildOpenAPISpec()
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatalf("marshaling spec: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+
+	paths, ok := decoded["paths"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("spec has no paths object: %v", decoded)
+	}
+
+	for _, want := range []string{"/api/v1/films", "/api/v1/films/{key}", "/api/v1/healthz"} {
+		if _, ok := paths[want]; !ok {
+			t.Errorf("spec paths missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/boltdb/bolt"
+)
+
+var filmsBucket = []byte("films")
+
+// boltStore is a Store backed by a local BoltDB file, selected via
+// SINK_STORE_BACKEND=bolt and SINK_BOLT_PATH.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func newBoltStore(path string) (*boltStore, error) {
+	if path == "" {
+		path = "sink.db"
+	}
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("opening bolt db %q: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(filmsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating films bucket: %w", err)
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(key string) (film, bool, error) {
+	var f film
+	var found bool
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(filmsBucket).Get([]byte(key))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &f)
+	})
+	return f, found, err
+}
+
+func (s *boltStore) List(name, year string) ([]film, error) {
+	var out []film
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(filmsBucket).ForEach(func(_, v []byte) error {
+			var f film
+			if err := json.Unmarshal(v, &f); err != nil {
+				return err
+			}
+			if name != "" && f.Title != name {
+				return nil
+			}
+			if year != "" && fmt.Sprintf("%d", f.Year) != year {
+				return nil
+			}
+			out = append(out, f)
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (s *boltStore) Put(key string, f film) error {
+	v, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(filmsBucket).Put([]byte(key), v)
+	})
+}
+
+func (s *boltStore) Delete(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(filmsBucket).Delete([]byte(key))
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
+)
+
+var chaosInjected = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "sink_chaos_injected_total",
+		Help: "The total requests where chaos was injected, by route and status",
+	},
+	[]string{"route", "status"},
+)
+
+// chaosRoutePolicy is the fault-injection policy for a single route.
+type chaosRoutePolicy struct {
+	FailureRate  float64 `json:"failure_rate"`
+	MinLatencyMS int     `json:"min_latency_ms"`
+	MaxLatencyMS int     `json:"max_latency_ms"`
+	Status       int     `json:"status"`
+}
+
+// chaosPolicy is the live, hot-reloadable chaos configuration, keyed by
+// route path template (e.g. "/{key}").
+type chaosPolicy struct {
+	mu     sync.RWMutex
+	Routes map[string]chaosRoutePolicy `json:"routes"`
+
+	rngMu sync.Mutex
+	rng   *rand.Rand
+}
+
+// newChaosPolicy builds a policy from SINK_CHAOS_CONFIG (a path to a JSON
+// config file) if set, falling back to the legacy default of a 20%
+// 503 rate on the /{key} route only. SINK_CHAOS_SEED seeds the RNG for
+// reproducible runs; it defaults to the current time.
+func newChaosPolicy() *chaosPolicy {
+	seed := time.Now().UnixNano()
+	if s := os.Getenv("SINK_CHAOS_SEED"); s != "" {
+		if parsed, err := strconv.ParseInt(s, 10, 64); err == nil {
+			seed = parsed
+		}
+	}
+
+	p := &chaosPolicy{
+		Routes: map[string]chaosRoutePolicy{
+			"/{key}": {FailureRate: 0.2, Status: http.StatusServiceUnavailable},
+		},
+		rng: rand.New(rand.NewSource(seed)),
+	}
+
+	if path := os.Getenv("SINK_CHAOS_CONFIG"); path != "" {
+		if err := p.loadFile(path); err != nil {
+			log.WithFields(logrus.Fields{"path": path, "error": err}).Error("loading chaos config")
+		}
+	}
+
+	return p
+}
+
+func (p *chaosPolicy) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading chaos config %q: %w", path, err)
+	}
+	return p.loadJSON(data)
+}
+
+func (p *chaosPolicy) loadJSON(data []byte) error {
+	var routes map[string]chaosRoutePolicy
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return fmt.Errorf("parsing chaos config: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.Routes = routes
+	return nil
+}
+
+// rngIntn and rngFloat64 serialize access to p.rng, which is not safe
+// for concurrent use on its own.
+func (p *chaosPolicy) rngIntn(n int) int {
+	p.rngMu.Lock()
+	defer p.rngMu.Unlock()
+	return p.rng.Intn(n)
+}
+
+func (p *chaosPolicy) rngFloat64() float64 {
+	p.rngMu.Lock()
+	defer p.rngMu.Unlock()
+	return p.rng.Float64()
+}
+
+func (p *chaosPolicy) snapshot() map[string]chaosRoutePolicy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	out := make(map[string]chaosRoutePolicy, len(p.Routes))
+	for k, v := range p.Routes {
+		out[k] = v
+	}
+	return out
+}
+
+// Middleware wraps a mux router, injecting latency and/or a failure
+// status on routes that have a policy configured.
+func (p *chaosPolicy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+
+		p.mu.RLock()
+		rp, ok := p.Routes[route]
+		p.mu.RUnlock()
+
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if rp.MaxLatencyMS > 0 {
+			lo, hi := rp.MinLatencyMS, rp.MaxLatencyMS
+			delay := lo
+			if hi > lo {
+				delay += p.rngIntn(hi - lo)
+			}
+			time.Sleep(time.Duration(delay) * time.Millisecond)
+		}
+
+		if rp.FailureRate > 0 && p.rngFloat64() < rp.FailureRate {
+			status := rp.Status
+			if status == 0 {
+				status = http.StatusServiceUnavailable
+			}
+
+			log.WithFields(logrus.Fields{
+				"method": r.Method,
+				"path":   r.URL.Path,
+				"route":  route,
+				"status": status,
+			}).Error("chaos injected")
+
+			chaosInjected.WithLabelValues(route, strconv.Itoa(status)).Inc()
+
+			w.WriteHeader(status)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tmpl, err := route.GetPathTemplate(); err == nil {
+			return tmpl
+		}
+	}
+	return r.URL.Path
+}
+
+// chaosHandler serves the current chaos policy on GET and replaces it
+// on POST, allowing operators to hot-reload without a restart.
+func chaosHandler(p *chaosPolicy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(p.snapshot())
+		case "POST":
+			data, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := p.loadJSON(data); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(p.snapshot())
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newTestPolicy(routes map[string]chaosRoutePolicy) *chaosPolicy {
+	return &chaosPolicy{
+		Routes: routes,
+		rng:    rand.New(rand.NewSource(1)),
+	}
+}
+
+// routedRequest returns the *http.Request the router hands to its
+// handler for pathTemplate, so mux.CurrentRoute (and therefore
+// routeTemplate) resolves the way it does in production.
+func routedRequest(t *testing.T, pathTemplate string) *http.Request {
+	t.Helper()
+
+	var captured *http.Request
+	r := mux.NewRouter()
+	r.HandleFunc(pathTemplate, func(w http.ResponseWriter, req *http.Request) {
+		captured = req
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", pathTemplate, nil))
+
+	if captured == nil {
+		t.Fatalf("router never matched %q", pathTemplate)
+	}
+	return captured
+}
+
+func TestChaosMiddlewareNoPolicyPassesThrough(t *testing.T) {
+	p := newTestPolicy(map[string]chaosRoutePolicy{})
+	req := routedRequest(t, "/unconfigured")
+
+	called := false
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if !called {
+		t.Fatal("expected next handler to run for a route with no chaos policy")
+	}
+}
+
+func TestChaosMiddlewareAlwaysInjects(t *testing.T) {
+	p := newTestPolicy(map[string]chaosRoutePolicy{
+		"/chaotic": {FailureRate: 1, Status: http.StatusTeapot},
+	})
+	req := routedRequest(t, "/chaotic")
+
+	called := false
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if called {
+		t.Fatal("next handler should not run when FailureRate is 1")
+	}
+	if rec.Code != http.StatusTeapot {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+}
+
+func TestChaosPolicyLoadJSONMalformed(t *testing.T) {
+	p := newTestPolicy(map[string]chaosRoutePolicy{
+		"/{key}": {FailureRate: 0.2},
+	})
+
+	if err := p.loadJSON([]byte("not json")); err == nil {
+		t.Fatal("expected an error for malformed chaos config JSON")
+	}
+
+	snap := p.snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("Routes should be unchanged after a failed load, got %v", snap)
+	}
+}
+
+func TestChaosPolicySnapshotIsDetached(t *testing.T) {
+	p := newTestPolicy(map[string]chaosRoutePolicy{
+		"/{key}": {FailureRate: 0.2},
+	})
+
+	snap := p.snapshot()
+	snap["/{key}"] = chaosRoutePolicy{FailureRate: 1}
+
+	again := p.snapshot()
+	if again["/{key}"].FailureRate != 0.2 {
+		t.Fatalf("mutating a snapshot leaked into the live policy: %v", again)
+	}
+}
+
+// TestChaosMiddlewareConcurrentRNGAccess exercises the middleware from
+// many goroutines at once; under `go test -race` this catches any
+// regression back to an unguarded *rand.Rand.
+func TestChaosMiddlewareConcurrentRNGAccess(t *testing.T) {
+	p := newTestPolicy(map[string]chaosRoutePolicy{
+		"/chaotic": {FailureRate: 0.5, MinLatencyMS: 0, MaxLatencyMS: 2},
+	})
+	req := routedRequest(t, "/chaotic")
+
+	h := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/ulikunitz/xz"
+)
+
+// maxDecompressedBytes bounds how much data decompressBody will read out
+// of an encoded body, to keep a maliciously crafted small payload from
+// expanding into a zip bomb.
+const maxDecompressedBytes = 64 << 20 // 64 MiB
+
+var decompressedRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "sink_decompressed_requests_total",
+		Help: "The total requests decompressed, by content encoding",
+	},
+	[]string{"encoding"},
+)
+
+// decompressBody wraps r in a decompressing reader based on encoding,
+// the value of the Content-Encoding header. An empty encoding returns r
+// unchanged. Unknown encodings return errUnsupportedEncoding.
+func decompressBody(encoding string, r io.ReadCloser) (io.ReadCloser, error) {
+	var dr io.Reader
+
+	switch encoding {
+	case "":
+		return r, nil
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("creating gzip reader: %w", err)
+		}
+		dr = gr
+	case "deflate":
+		dr = flate.NewReader(r)
+	case "bzip2":
+		dr = bzip2.NewReader(r)
+	case "xz":
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("creating xz reader: %w", err)
+		}
+		dr = xr
+	default:
+		return nil, errUnsupportedEncoding(encoding)
+	}
+
+	decompressedRequests.WithLabelValues(encoding).Inc()
+
+	limited := io.LimitReader(dr, maxDecompressedBytes)
+	return ioutil.NopCloser(limited), nil
+}
+
+// errUnsupportedEncoding is returned by decompressBody for any
+// Content-Encoding it doesn't know how to handle; post maps it to a
+// 415 response.
+type errUnsupportedEncoding string
+
+func (e errUnsupportedEncoding) Error() string {
+	return fmt.Sprintf("unsupported content encoding: %s", string(e))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func deflateBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		t.Fatalf("flate writer: %v", err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		t.Fatalf("flate write: %v", err)
+	}
+	if err := fw.Close(); err != nil {
+		t.Fatalf("flate close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBodyRoundTrip(t *testing.T) {
+	want := []byte(`{"title":"Arrival","year":2016}`)
+
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", want},
+		{"gzip", gzipBytes(t, want)},
+		{"deflate", deflateBytes(t, want)},
+	}
+
+	for _, tt := range tests {
+		rc, err := decompressBody(tt.encoding, ioutil.NopCloser(bytes.NewReader(tt.body)))
+		if err != nil {
+			t.Fatalf("decompressBody(%q): %v", tt.encoding, err)
+		}
+		got, err := ioutil.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("reading decompressed body for %q: %v", tt.encoding, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("decompressBody(%q) = %q, want %q", tt.encoding, got, want)
+		}
+	}
+}
+
+func TestDecompressBodyUnsupportedEncoding(t *testing.T) {
+	_, err := decompressBody("br", ioutil.NopCloser(bytes.NewReader(nil)))
+	if err == nil {
+		t.Fatal("expected an error for an unsupported encoding")
+	}
+	if _, ok := err.(errUnsupportedEncoding); !ok {
+		t.Fatalf("expected errUnsupportedEncoding, got %T: %v", err, err)
+	}
+}
+
+func TestDecompressBodyBadGzip(t *testing.T) {
+	_, err := decompressBody("gzip", ioutil.NopCloser(bytes.NewReader([]byte("not gzip"))))
+	if err == nil {
+		t.Fatal("expected an error for a malformed gzip stream")
+	}
+}
+
+func TestDecompressBodyBzip2PassesThroughUnderlyingErrors(t *testing.T) {
+	// bzip2.NewReader defers error detection to the first Read, unlike
+	// gzip.NewReader which validates the header eagerly; decompressBody
+	// should surface that on read rather than at construction time.
+	rc, err := decompressBody("bzip2", ioutil.NopCloser(bytes.NewReader([]byte("not bzip2"))))
+	if err != nil {
+		t.Fatalf("decompressBody(bzip2) construction: %v", err)
+	}
+	if _, err := ioutil.ReadAll(rc); err == nil {
+		t.Fatal("expected a read error for a malformed bzip2 stream")
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRequestTimeout caps the per-request deadline a client can request
+// via the X-Request-Timeout header, regardless of what it asks for.
+const maxRequestTimeout = 30 * time.Second
+
+// serverConfig holds the env-configurable parts of the server's
+// lifecycle: listen address, base timeouts, shutdown grace period, and
+// log level.
+type serverConfig struct {
+	Addr          string
+	ReadTimeout   time.Duration
+	WriteTimeout  time.Duration
+	ShutdownGrace time.Duration
+	LogLevel      log.Level
+}
+
+// loadConfig reads SINK_ADDR, SINK_READ_TIMEOUT, SINK_WRITE_TIMEOUT,
+// SINK_SHUTDOWN_GRACE, and SINK_LOG_LEVEL, falling back to the
+// historical defaults when unset or unparsable.
+func loadConfig() serverConfig {
+	return serverConfig{
+		Addr:          getEnv("SINK_ADDR", ":9009"),
+		ReadTimeout:   getDurationEnv("SINK_READ_TIMEOUT", 2*time.Second),
+		WriteTimeout:  getDurationEnv("SINK_WRITE_TIMEOUT", 2*time.Second),
+		ShutdownGrace: getDurationEnv("SINK_SHUTDOWN_GRACE", 10*time.Second),
+		LogLevel:      getLogLevelEnv("SINK_LOG_LEVEL", log.InfoLevel),
+	}
+}
+
+func getEnv(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func getDurationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.WithFields(log.Fields{"var": name, "value": v, "error": err}).Warn("invalid duration env var, using default")
+		return def
+	}
+	return d
+}
+
+func getLogLevelEnv(name string, def log.Level) log.Level {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	lvl, err := log.ParseLevel(v)
+	if err != nil {
+		log.WithFields(log.Fields{"var": name, "value": v, "error": err}).Warn("invalid log level env var, using default")
+		return def
+	}
+	return lvl
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	log "github.com/sirupsen/logrus"
+)
+
+// requestDeadlineMiddleware lets a client ask for a shorter per-request
+// deadline via X-Request-Timeout (a Go duration string), capped at
+// maxRequestTimeout so a client can't hold a handler open indefinitely.
+// The handler runs in its own goroutine so a 504 can be written as soon
+// as the deadline fires, even if the handler itself is still blocked
+// downstream (e.g. on a slow Store call).
+func requestDeadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, err := parseRequestTimeout(r.Header.Get("X-Request-Timeout"))
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"path":  r.URL.Path,
+				"value": r.Header.Get("X-Request-Timeout"),
+				"error": err,
+			}).Warn("invalid X-Request-Timeout header, using max")
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{w: w}
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			log.WithFields(logrus.Fields{
+				"path":    r.URL.Path,
+				"timeout": timeout.String(),
+			}).Warn("request deadline exceeded")
+
+			tw.timeout()
+		}
+	})
+}
+
+// parseRequestTimeout parses raw (the X-Request-Timeout header value) as
+// a Go duration, capped at maxRequestTimeout. An empty or unparsable raw
+// falls back to maxRequestTimeout; in the unparsable case it's also
+// reported via a non-nil error so the caller can log it.
+func parseRequestTimeout(raw string) (time.Duration, error) {
+	if raw == "" {
+		return maxRequestTimeout, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return maxRequestTimeout, err
+	}
+	if d > maxRequestTimeout {
+		d = maxRequestTimeout
+	}
+	return d, nil
+}
+
+// timeoutWriter guards an http.ResponseWriter so that once the deadline
+// fires and a 504 has been written, a still-running handler can't also
+// write to (and corrupt) the response.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.w.Header()
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.w.WriteHeader(status)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return len(b), nil
+	}
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		tw.w.WriteHeader(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+// timeout marks the writer as timed out and writes the 504, unless the
+// handler already wrote a response first.
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return
+	}
+	tw.timedOut = true
+	tw.w.WriteHeader(http.StatusGatewayTimeout)
+	tw.w.Write([]byte("request deadline exceeded\n"))
+}
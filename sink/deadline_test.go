@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRequestTimeout(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", maxRequestTimeout, false},
+		{"5ms", 5 * time.Millisecond, false},
+		{"garbage", maxRequestTimeout, true},
+		{"1h", maxRequestTimeout, false}, // capped
+	}
+
+	for _, tt := range tests {
+		got, err := parseRequestTimeout(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRequestTimeout(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("parseRequestTimeout(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestRequestDeadlineMiddlewareFastHandler(t *testing.T) {
+	h := requestDeadlineMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest("POST", "/a", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec.Body.String() != "ok" {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), "ok")
+	}
+}
+
+func TestRequestDeadlineMiddlewareTimesOutAndSwallowsLateWrite(t *testing.T) {
+	handlerDone := make(chan struct{})
+
+	h := requestDeadlineMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		time.Sleep(50 * time.Millisecond)
+		// The deadline has already fired by the time we get here; this
+		// write must be swallowed, not appended to the 504 already sent.
+		w.Write([]byte("late"))
+	}))
+
+	req := httptest.NewRequest("POST", "/a", nil)
+	req.Header.Set("X-Request-Timeout", "5ms")
+	rec := httptest.NewRecorder()
+
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusGatewayTimeout)
+	}
+
+	<-handlerDone // wait for the late write to actually happen before inspecting the body
+
+	if rec.Body.String() != "request deadline exceeded\n" {
+		t.Fatalf("body = %q, want only the timeout message (late write should be swallowed)", rec.Body.String())
+	}
+}
@@ -1,43 +1,26 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"regexp"
-	"strconv"
 	"strings"
+	"syscall"
 	"time"
 	"unicode/utf8"
 
 	"github.com/gorilla/mux"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	log "github.com/sirupsen/logrus"
 )
 
-var (
-	getsProcessed = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "sink_get_total",
-			Help: "The total get calls",
-		},
-		[]string{"status"}, // add label for http status
-	)
-
-	postsProcessed = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "sink_post_total",
-			Help: "The total post calls",
-		},
-		[]string{"status"}, // add label for http status
-	)
-)
+var store Store
 
 func init() {
 	// Log as JSON instead of the default ASCII formatter.
@@ -52,23 +35,57 @@ func init() {
 }
 
 func main() {
+	cfg := loadConfig()
+	log.SetLevel(cfg.LogLevel)
+
+	var err error
+	store, err = newStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	chaos := newChaosPolicy()
+
 	r := mux.NewRouter()
 	r.Handle("/metrics", promhttp.Handler())
-	r.HandleFunc("/{key}", mainHandler)
+	r.HandleFunc("/chaos", chaosHandler(chaos))
+	registerV1Routes(r)
+	r.HandleFunc("/{key}", legacyHandler)
+	r.Use(metricsMiddleware)
+	r.Use(chaos.Middleware)
+	r.Use(requestDeadlineMiddleware)
 
 	log.WithFields(logrus.Fields{
-		"port": "9009",
+		"addr": cfg.Addr,
 	}).Info("starting http sink")
 
 	srv := &http.Server{
 		Handler:      r,
-		Addr:         ":9009",
-		ReadTimeout:  2 * time.Second,
-		WriteTimeout: 2 * time.Second,
+		Addr:         cfg.Addr,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal(err)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-sigCh
+
+	log.WithFields(logrus.Fields{
+		"signal": sig.String(),
+		"grace":  cfg.ShutdownGrace.String(),
+	}).Info("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGrace)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.WithFields(logrus.Fields{"error": err}).Error("graceful shutdown failed")
 	}
 }
 
@@ -78,6 +95,10 @@ func mainHandler(w http.ResponseWriter, r *http.Request) {
 		get(w, r)
 	case "POST":
 		post(w, r)
+	case "PUT":
+		put(w, r)
+	case "DELETE":
+		deleteFilm(w, r)
 	default:
 		log.WithFields(logrus.Fields{
 			"method": r.Method,
@@ -93,41 +114,138 @@ func mainHandler(w http.ResponseWriter, r *http.Request) {
 func get(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 	name := query.Get("name")
+	year := query.Get("year")
 
 	log.WithFields(logrus.Fields{
 		"method": r.Method,
 		"path":   r.URL.Path,
 		"name":   name,
+		"year":   year,
 	}).Info("GET request")
 
-	getsProcessed.WithLabelValues(strconv.Itoa(http.StatusOK)).Inc()
+	films, err := store.List(name, year)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"error":  err,
+		}).Error("return 500")
 
-	w.Write([]byte(fmt.Sprintln("[]")))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if films == nil {
+		films = []film{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(films)
 }
 
-func post(w http.ResponseWriter, r *http.Request) {
-	val := rand.Intn(100)
+func put(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
 
-	if val < 20 {
+	body, err := decompressBody(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"encoding": r.Header.Get("Content-Encoding"),
+			"error":    err,
+		}).Error("return 415")
+
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
 		log.WithFields(logrus.Fields{
 			"method": r.Method,
 			"path":   r.URL.Path,
-		}).Error("return 503")
+		}).Error("return 500")
 
-		postsProcessed.WithLabelValues(strconv.Itoa(http.StatusServiceUnavailable)).Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-		w.WriteHeader(http.StatusServiceUnavailable)
+	var f film
+	if err := json.Unmarshal(data, &f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := f.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	data, err := ioutil.ReadAll(r.Body)
-	if err != nil {
+	if err := store.Put(key, f); err != nil {
 		log.WithFields(logrus.Fields{
 			"method": r.Method,
 			"path":   r.URL.Path,
+			"error":  err,
 		}).Error("return 500")
 
-		postsProcessed.WithLabelValues(strconv.Itoa(http.StatusInternalServerError)).Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"key":    key,
+	}).Info("PUT request")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(f)
+}
+
+func deleteFilm(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	if err := store.Delete(key); err != nil {
+		log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"error":  err,
+		}).Error("return 500")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	log.WithFields(logrus.Fields{
+		"method": r.Method,
+		"path":   r.URL.Path,
+		"key":    key,
+	}).Info("DELETE request")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func post(w http.ResponseWriter, r *http.Request) {
+	body, err := decompressBody(r.Header.Get("Content-Encoding"), r.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"method":   r.Method,
+			"path":     r.URL.Path,
+			"encoding": r.Header.Get("Content-Encoding"),
+			"error":    err,
+		}).Error("return 415")
+
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+		}).Error("return 500")
 
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -142,21 +260,36 @@ func post(w http.ResponseWriter, r *http.Request) {
 			"body":   string(data),
 		}).Error("return 400")
 
-		postsProcessed.WithLabelValues(strconv.Itoa(http.StatusBadRequest)).Inc()
-
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	key := mux.Vars(r)["key"]
+
+	var f film
+	json.Unmarshal(data, &f) // already validated above
+
+	if err := store.Put(key, f); err != nil {
+		log.WithFields(logrus.Fields{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"error":  err,
+		}).Error("return 500")
+
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
 	log.WithFields(logrus.Fields{
 		"method": r.Method,
 		"path":   r.URL.Path,
+		"key":    key,
 		"body":   string(data),
 	}).Info("POST request")
 
-	postsProcessed.WithLabelValues(strconv.Itoa(http.StatusCreated)).Inc()
-
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(f)
 }
 
 func validateFilm(data []byte) error {
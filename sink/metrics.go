@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "sink_http_request_duration_seconds",
+			Help: "Request latency in seconds",
+		},
+		[]string{"method", "route", "status"},
+	)
+
+	requestSize = promauto.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name: "sink_http_request_size_bytes",
+			Help: "Request body size in bytes",
+		},
+		[]string{"method", "route"},
+	)
+
+	responseSize = promauto.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Name: "sink_http_response_size_bytes",
+			Help: "Response body size in bytes",
+		},
+		[]string{"method", "route", "status"},
+	)
+)
+
+// statusRecorder wraps an http.ResponseWriter to capture the status
+// code and byte count actually written, since a handler doesn't always
+// call WriteHeader explicitly.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.wroteHeader = true
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.written += int64(n)
+	return n, err
+}
+
+// metricsMiddleware records sink_http_request_duration_seconds,
+// sink_http_request_size_bytes, and sink_http_response_size_bytes for
+// every request that matches a route (mux runs r.Use middleware only on
+// a match), replacing the old per-handler counter Inc() calls.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := routeTemplate(r)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		status := strconv.Itoa(rec.status)
+
+		requestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+		requestSize.WithLabelValues(r.Method, route).Observe(float64(requestContentLength(r)))
+		responseSize.WithLabelValues(r.Method, route, status).Observe(float64(rec.written))
+	})
+}
+
+func requestContentLength(r *http.Request) int64 {
+	if r.ContentLength > 0 {
+		return r.ContentLength
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(w http.ResponseWriter)
+		wantStatus  int
+		wantWritten int64
+	}{
+		{
+			name: "implicit 200 from a bare Write",
+			handler: func(w http.ResponseWriter) {
+				w.Write([]byte("hello"))
+			},
+			wantStatus:  http.StatusOK,
+			wantWritten: 5,
+		},
+		{
+			name: "multiple writes accumulate",
+			handler: func(w http.ResponseWriter) {
+				w.Write([]byte("foo"))
+				w.Write([]byte("bar"))
+				w.Write([]byte("baz"))
+			},
+			wantStatus:  http.StatusOK,
+			wantWritten: 9,
+		},
+		{
+			name: "explicit non-200 status is captured",
+			handler: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("short and stout"))
+			},
+			wantStatus:  http.StatusTeapot,
+			wantWritten: 15,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			rec := &statusRecorder{ResponseWriter: rr, status: http.StatusOK}
+
+			tt.handler(rec)
+
+			if rec.status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.status, tt.wantStatus)
+			}
+			if rec.written != tt.wantWritten {
+				t.Errorf("written = %d, want %d", rec.written, tt.wantWritten)
+			}
+			if rr.Code != tt.wantStatus {
+				t.Errorf("underlying recorder code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMetricsMiddlewareDefaultsToOKWhenNoWriteHeaderCalled(t *testing.T) {
+	h := metricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest("GET", "/unrouted", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "ok" {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), "ok")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"reflect"
+	"time"
+)
+
+// buildOpenAPISpec derives an OpenAPI 3.0 document for the film
+// resource from the film struct's json tags, plus the same validation
+// rules enforced by film.validate.
+func buildOpenAPISpec() map[string]interface{} {
+	return map[string]interface{}{
+		"openapi": "3.0.0",
+		"info": map[string]interface{}{
+			"title":   "sink",
+			"version": "1.0.0",
+		},
+		"paths": map[string]interface{}{
+			"/api/v1/films": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary": "List films",
+					"parameters": []map[string]interface{}{
+						{"name": "name", "in": "query", "schema": map[string]string{"type": "string"}},
+						{"name": "year", "in": "query", "schema": map[string]string{"type": "string"}},
+					},
+					"responses": map[string]interface{}{
+						"200": envelopeResponse(map[string]interface{}{"type": "array", "items": filmSchema()}),
+					},
+				},
+			},
+			"/api/v1/films/{key}": map[string]interface{}{
+				"get":    filmByKeyOperation("Get a film", "200"),
+				"put":    filmWriteOperation("Replace a film", "200"),
+				"post":   filmWriteOperation("Create a film", "201"),
+				"delete": filmByKeyOperation("Delete a film", "200"),
+			},
+			"/api/v1/healthz": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":   "Health check",
+					"responses": map[string]interface{}{"200": envelopeResponse(map[string]string{"type": "object"})},
+				},
+			},
+		},
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"film": filmSchema(),
+			},
+		},
+	}
+}
+
+func filmByKeyOperation(summary, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"summary":    summary,
+		"parameters": []map[string]interface{}{keyParameter()},
+		"responses":  map[string]interface{}{status: envelopeResponse(filmSchema())},
+	}
+}
+
+func filmWriteOperation(summary, status string) map[string]interface{} {
+	op := filmByKeyOperation(summary, status)
+	op["requestBody"] = map[string]interface{}{
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": filmSchema()},
+		},
+	}
+	return op
+}
+
+func keyParameter() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     "key",
+		"in":       "path",
+		"required": true,
+		"schema":   map[string]string{"type": "string"},
+	}
+}
+
+func envelopeResponse(dataSchema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": "envelope",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{
+				"schema": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"status": map[string]interface{}{"type": "string", "enum": []string{"success", "error"}},
+						"data":   dataSchema,
+						"error":  map[string]string{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// filmSchema reflects over the film struct's json tags to build the
+// property list, then layers on the validation rules from
+// film.validate that can't be read off the Go types alone.
+func filmSchema() map[string]interface{} {
+	properties := map[string]interface{}{}
+
+	t := reflect.TypeOf(film{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("json")
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = jsonSchemaForKind(f.Type.Kind())
+	}
+
+	properties["title"] = map[string]interface{}{
+		"type":      "string",
+		"minLength": 1,
+	}
+	properties["year"] = map[string]interface{}{
+		"type":    "integer",
+		"minimum": 1888,
+		"maximum": time.Now().Year(),
+	}
+	properties["popularity"] = map[string]interface{}{
+		"type":    "number",
+		"minimum": 0,
+		"maximum": 100,
+	}
+	properties["awards"] = map[string]interface{}{
+		"type": "string",
+		"enum": []string{"", "Yes", "No"},
+	}
+	properties["image"] = map[string]interface{}{
+		"type":    "string",
+		"pattern": "^$|" + imagePattern.String(),
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"required":   []string{"title"},
+		"properties": properties,
+	}
+}
+
+func jsonSchemaForKind(k reflect.Kind) map[string]interface{} {
+	switch k {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
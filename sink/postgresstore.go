@@ -0,0 +1,106 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/lib/pq"
+)
+
+// postgresStore is a Store backed by Postgres, selected via
+// SINK_STORE_BACKEND=postgres and SINK_POSTGRES_DSN. Films are stored
+// as JSON rather than one column per field so the schema can absorb
+// new film fields without a migration.
+type postgresStore struct {
+	db *sql.DB
+}
+
+func newPostgresStore(dsn string) (*postgresStore, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("SINK_POSTGRES_DSN is required for the postgres backend")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening postgres: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("pinging postgres: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS films (
+		key TEXT PRIMARY KEY,
+		data JSONB NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("creating films table: %w", err)
+	}
+
+	return &postgresStore{db: db}, nil
+}
+
+func (s *postgresStore) Get(key string) (film, bool, error) {
+	var f film
+	var data []byte
+
+	err := s.db.QueryRow(`SELECT data FROM films WHERE key = $1`, key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return f, false, nil
+	}
+	if err != nil {
+		return f, false, err
+	}
+
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, false, err
+	}
+	return f, true, nil
+}
+
+func (s *postgresStore) List(name, year string) ([]film, error) {
+	rows, err := s.db.Query(`SELECT data FROM films`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []film
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var f film
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, err
+		}
+		if name != "" && f.Title != name {
+			continue
+		}
+		if year != "" && fmt.Sprintf("%d", f.Year) != year {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (s *postgresStore) Put(key string, f film) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO films (key, data) VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE SET data = EXCLUDED.data`, key, data)
+	return err
+}
+
+func (s *postgresStore) Delete(key string) error {
+	_, err := s.db.Exec(`DELETE FROM films WHERE key = $1`, key)
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Store is the persistence backend for accepted films, keyed by the
+// mux "{key}" path variable. Implementations must be safe for
+// concurrent use.
+type Store interface {
+	Get(key string) (film, bool, error)
+	List(name, year string) ([]film, error)
+	Put(key string, f film) error
+	Delete(key string) error
+}
+
+// newStore selects a Store implementation based on the SINK_STORE_BACKEND
+// env var. It defaults to the in-memory backend when unset.
+func newStore() (Store, error) {
+	switch backend := os.Getenv("SINK_STORE_BACKEND"); backend {
+	case "", "memory":
+		return newMemoryStore(), nil
+	case "bolt":
+		return newBoltStore(os.Getenv("SINK_BOLT_PATH"))
+	case "postgres":
+		return newPostgresStore(os.Getenv("SINK_POSTGRES_DSN"))
+	default:
+		return nil, fmt.Errorf("unknown SINK_STORE_BACKEND %q", backend)
+	}
+}
+
+// memoryStore is a Store backed by a guarded map. It is the default
+// backend and is primarily useful for local development and tests.
+type memoryStore struct {
+	mu    sync.RWMutex
+	films map[string]film
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{films: make(map[string]film)}
+}
+
+func (s *memoryStore) Get(key string) (film, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.films[key]
+	return f, ok, nil
+}
+
+func (s *memoryStore) List(name, year string) ([]film, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]film, 0, len(s.films))
+	for _, f := range s.films {
+		if name != "" && f.Title != name {
+			continue
+		}
+		if year != "" && strconv.Itoa(f.Year) != year {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Put(key string, f film) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.films[key] = f
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.films, key)
+	return nil
+}
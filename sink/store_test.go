@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMemoryStorePutGetDelete(t *testing.T) {
+	s := newMemoryStore()
+
+	if _, ok, _ := s.Get("a"); ok {
+		t.Fatalf("expected no film for unset key")
+	}
+
+	f := film{Title: "Arrival", Year: 2016}
+	if err := s.Put("a", f); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, ok, err := s.Get("a")
+	if err != nil || !ok {
+		t.Fatalf("Get after Put: got=%v ok=%v err=%v", got, ok, err)
+	}
+	if got != f {
+		t.Fatalf("Get returned %+v, want %+v", got, f)
+	}
+
+	if err := s.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok, _ := s.Get("a"); ok {
+		t.Fatalf("expected film to be gone after Delete")
+	}
+}
+
+func TestMemoryStoreListFilters(t *testing.T) {
+	s := newMemoryStore()
+	s.Put("a", film{Title: "Arrival", Year: 2016})
+	s.Put("b", film{Title: "Tenet", Year: 2020})
+	s.Put("c", film{Title: "Arrival", Year: 2016})
+
+	tests := []struct {
+		name    string
+		year    string
+		wantLen int
+	}{
+		{"", "", 3},
+		{"Arrival", "", 2},
+		{"", "2020", 1},
+		{"Arrival", "2020", 0},
+		{"Nope", "", 0},
+	}
+
+	for _, tt := range tests {
+		films, err := s.List(tt.name, tt.year)
+		if err != nil {
+			t.Fatalf("List(%q, %q): %v", tt.name, tt.year, err)
+		}
+		if len(films) != tt.wantLen {
+			t.Errorf("List(%q, %q) = %d films, want %d", tt.name, tt.year, len(films), tt.wantLen)
+		}
+	}
+}
+
+func TestNewStoreBackend(t *testing.T) {
+	tests := []struct {
+		backend string
+		wantErr bool
+	}{
+		{"", false},
+		{"memory", false},
+		{"bogus", true},
+	}
+
+	for _, tt := range tests {
+		os.Setenv("SINK_STORE_BACKEND", tt.backend)
+		_, err := newStore()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newStore() with SINK_STORE_BACKEND=%q: err=%v, wantErr=%v", tt.backend, err, tt.wantErr)
+		}
+	}
+	os.Unsetenv("SINK_STORE_BACKEND")
+}